@@ -0,0 +1,115 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RESIZE_HMAC_SECRET 에 담긴 공유 비밀로 (objectKey, width, height, format, quality, expiry) 를
+// 서명한다. SSM Parameter Store 등에서 이 환경변수로 주입하는 것을 전제로 한다.
+const hmacSecretEnv = "RESIZE_HMAC_SECRET"
+
+// RESIZE_ALLOWED_PRESETS 는 서명 없이도 허용할 "WIDTHxHEIGHT" 쌍의 콤마 구분 allow-list.
+// 예) "256x256,768x0" - 공개 썸네일 URL 용도로, 임의 조합의 비용/캐시 폭증을 막기 위함.
+const allowedPresetsEnv = "RESIZE_ALLOWED_PRESETS"
+
+// ErrUnauthorized 는 서명이 없거나, 잘못되었거나, 만료되었고 preset allow-list 에도 없을 때 반환된다.
+var ErrUnauthorized = fmt.Errorf("UNSIGNED_OR_EXPIRED_REQUEST")
+
+// authorizeResize 는 요청이 유효한 서명을 들고 있거나, 허가된 preset 크기에 해당하는지 검사한다.
+// 둘 중 하나도 만족하지 못하면 ErrUnauthorized 를 리턴한다.
+func authorizeResize(params map[string]string, config *RequestConfig, format string, quality int) error {
+	secret := os.Getenv(hmacSecretEnv)
+	sig := params["sig"]
+	expiryRaw := params["expiry"]
+
+	if secret != "" && sig != "" {
+		if err := verifySignature(secret, sig, expiryRaw, config, format, quality); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	// smart fit 은 엔트로피 스캔을 돌리는 비싼 연산이라, 서명 없이 preset 경유로는 허용하지 않는다 -
+	// 그렇지 않으면 서명/preset 체크가 덮지 않는 fit=smart 를 아무 preset URL 에나 덧붙여 비용을 태울 수 있다.
+	if config.Fit == FitSmart {
+		return ErrUnauthorized
+	}
+
+	if isAllowedPreset(config.Width, config.Height) {
+		return nil
+	}
+
+	return ErrUnauthorized
+}
+
+// verifySignature 는 만료시간과 HMAC-SHA256 서명을 검증한다.
+func verifySignature(secret, sig, expiryRaw string, config *RequestConfig, format string, quality int) error {
+	expiry, err := strconv.ParseInt(expiryRaw, 10, 64)
+	if err != nil {
+		return ErrUnauthorized
+	}
+	if time.Unix(expiry, 0).Before(time.Now()) {
+		return ErrUnauthorized
+	}
+
+	expected := signPayload(secret, config, format, quality, expiry)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(sig))) != 1 {
+		return ErrUnauthorized
+	}
+
+	return nil
+}
+
+// signPayload 는 (objectKey, width, height, format, quality, fit, anchor, focus, expiry) 에 대한
+// hex 인코딩된 HMAC-SHA256 를 계산한다. fit/gravity/focus 를 빼면 서명된 URL 에 그 파라미터들만
+// 공짜로 덧붙여 smart-crop 같은 비싼 연산을 무단으로 돌릴 수 있으므로 반드시 서명에 포함한다.
+func signPayload(secret string, config *RequestConfig, format string, quality int, expiry int64) string {
+	payload := strings.Join([]string{
+		config.ObjectKey,
+		strconv.Itoa(config.Width),
+		strconv.Itoa(config.Height),
+		format,
+		strconv.Itoa(quality),
+		string(config.Fit),
+		strconv.Itoa(int(config.Anchor)),
+		focusString(config.Focus),
+		strconv.FormatInt(expiry, 10),
+	}, "|")
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// focusString 은 focus 포인트를 서명 페이로드에 넣을 수 있는 결정적인 문자열로 직렬화한다.
+func focusString(focus *focusPoint) string {
+	if focus == nil {
+		return ""
+	}
+	return strconv.FormatFloat(focus.X, 'f', -1, 64) + "," + strconv.FormatFloat(focus.Y, 'f', -1, 64)
+}
+
+// isAllowedPreset 은 (width, height) 가 RESIZE_ALLOWED_PRESETS 에 등록된 조합인지 확인한다.
+func isAllowedPreset(width, height int) bool {
+	raw := os.Getenv(allowedPresetsEnv)
+	if raw == "" {
+		return false
+	}
+
+	target := fmt.Sprintf("%dx%d", width, height)
+	for _, preset := range strings.Split(raw, ",") {
+		if strings.TrimSpace(preset) == target {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func withHMACSecret(t *testing.T, secret string) {
+	t.Helper()
+	old := os.Getenv(hmacSecretEnv)
+	os.Setenv(hmacSecretEnv, secret)
+	t.Cleanup(func() { os.Setenv(hmacSecretEnv, old) })
+}
+
+func TestVerifySignatureAcceptsValidSignature(t *testing.T) {
+	config := &RequestConfig{ObjectKey: "/a.png", Width: 256, Height: 256, Fit: FitCover}
+	expiry := time.Now().Add(time.Hour).Unix()
+	sig := signPayload("secret", config, "jpeg", 80, expiry)
+
+	if err := verifySignature("secret", sig, strconv.FormatInt(expiry, 10), config, "jpeg", 80); err != nil {
+		t.Fatalf("expected valid signature to verify, got %v", err)
+	}
+}
+
+func TestVerifySignatureRejectsExpiredRequest(t *testing.T) {
+	config := &RequestConfig{ObjectKey: "/a.png", Width: 256, Height: 256}
+	expiry := time.Now().Add(-time.Hour).Unix()
+	sig := signPayload("secret", config, "jpeg", 80, expiry)
+
+	if err := verifySignature("secret", sig, strconv.FormatInt(expiry, 10), config, "jpeg", 80); err == nil {
+		t.Fatalf("expected expired signature to be rejected")
+	}
+}
+
+func TestVerifySignatureRejectsTamperedFitParam(t *testing.T) {
+	config := &RequestConfig{ObjectKey: "/a.png", Width: 256, Height: 256, Fit: FitCover}
+	expiry := time.Now().Add(time.Hour).Unix()
+	sig := signPayload("secret", config, "jpeg", 80, expiry)
+
+	// 서명 이후 공격자가 fit 을 smart 로 바꿔치기 했다고 가정 - 서명은 더 이상 일치하지 않아야 한다
+	tampered := *config
+	tampered.Fit = FitSmart
+
+	if err := verifySignature("secret", sig, strconv.FormatInt(expiry, 10), &tampered, "jpeg", 80); err == nil {
+		t.Fatalf("expected signature to be invalidated when fit is tampered with after signing")
+	}
+}
+
+func TestVerifySignatureRejectsTamperedFocusParam(t *testing.T) {
+	config := &RequestConfig{ObjectKey: "/a.png", Width: 256, Height: 256, Fit: FitCover, Focus: &focusPoint{X: 0.2, Y: 0.8}}
+	expiry := time.Now().Add(time.Hour).Unix()
+	sig := signPayload("secret", config, "jpeg", 80, expiry)
+
+	tampered := *config
+	tampered.Focus = &focusPoint{X: 0.9, Y: 0.1}
+
+	if err := verifySignature("secret", sig, strconv.FormatInt(expiry, 10), &tampered, "jpeg", 80); err == nil {
+		t.Fatalf("expected signature to be invalidated when focus is tampered with after signing")
+	}
+}
+
+func TestAuthorizeResizeRejectsUnsignedSmartFitEvenForAllowedPreset(t *testing.T) {
+	withHMACSecret(t, "")
+	old := os.Getenv(allowedPresetsEnv)
+	os.Setenv(allowedPresetsEnv, "256x256")
+	t.Cleanup(func() { os.Setenv(allowedPresetsEnv, old) })
+
+	config := &RequestConfig{ObjectKey: "/a.png", Width: 256, Height: 256, Fit: FitSmart}
+
+	if err := authorizeResize(map[string]string{}, config, "jpeg", 80); err == nil {
+		t.Fatalf("expected unsigned fit=smart request to be rejected even at an allow-listed preset size")
+	}
+}
+
+func TestAuthorizeResizeAllowsUnsignedScaleFitAtAllowedPreset(t *testing.T) {
+	withHMACSecret(t, "")
+	old := os.Getenv(allowedPresetsEnv)
+	os.Setenv(allowedPresetsEnv, "256x256")
+	t.Cleanup(func() { os.Setenv(allowedPresetsEnv, old) })
+
+	config := &RequestConfig{ObjectKey: "/a.png", Width: 256, Height: 256, Fit: FitScale}
+
+	if err := authorizeResize(map[string]string{}, config, "jpeg", 80); err != nil {
+		t.Fatalf("expected unsigned scale-fit request at an allow-listed preset size to be authorized, got %v", err)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build avif
+
+package main
+
+import (
+	"image"
+	"io"
+
+	"github.com/Kagami/go-avif"
+)
+
+// registerAvifEncoder 는 avif 빌드 태그가 켜진 바이너리에서 encoderRegistry 에 "avif" 인코더를 채운다.
+// go-avif 는 libaom 에 대한 cgo 바인딩이라 기본 빌드에서는 끌어오지 않는다 (avif_stub.go 참고).
+func registerAvifEncoder() {
+	encoderRegistry["avif"] = func(dst io.Writer, img image.Image, quality int) error {
+		return avif.Encode(dst, img, &avif.Options{Quality: quality})
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !avif
+
+package main
+
+// registerAvifEncoder 는 avif 빌드 태그 없이 컴파일된 기본 빌드(로컬 개발, CI, 기존 Lambda 빌드)에서
+// 호출된다 - libaom 의 C 헤더가 없어도 빌드가 되도록 아무것도 등록하지 않는다.
+// encoderRegistry 에 "avif" 가 없으면 negotiateFormat/encodeByFormat 이 자동으로 다른 포맷으로 떨어진다.
+func registerAvifEncoder() {}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/disintegration/imaging"
+)
+
+var errInvalidLimit = errors.New("invalid limit value")
+
+// 디코딩을 허용할 최대 소스 바이트 수 - 넘어서면 읽기 자체를 거부한다
+const defaultMaxSourceBytes = 32 * 1024 * 1024
+
+// 디코딩된 이미지가 가질 수 있는 최대 픽셀 수 (width * height) - 병적으로 큰 이미지로 인한 OOM 방지
+const defaultMaxPixels = 40_000_000
+
+// Params 는 Backend.Resize 에 전달되는 리사이즈 파라미터
+type Params struct {
+	Width          int
+	Height         int
+	Format         string
+	Quality        int
+	Fit            Fit
+	Anchor         imaging.Anchor
+	Focus          *focusPoint
+	MaxSourceBytes int64
+	MaxPixels      int64
+}
+
+// Stats 는 한 번의 Resize 호출에 대한 타이밍/크기 정보 - CloudWatch EMF 메트릭으로 그대로 실린다.
+type Stats struct {
+	SourceBytes    int64
+	DecodedPixels  int64
+	OutputBytes    int64
+	DecodeDuration time.Duration
+	ResizeDuration time.Duration
+	EncodeDuration time.Duration
+}
+
+// Backend 는 실제 디코딩/리사이징/인코딩을 수행하는 구현체가 만족해야 하는 인터페이스.
+// 같은 인터페이스 뒤에 순수 Go 구현(imaging)과 libvips 구현(govips)을 둘 다 꽂을 수 있다.
+type Backend interface {
+	// Name 은 로그/메트릭에서 어떤 backend 가 쓰였는지 구분하기 위한 식별자
+	Name() string
+	// Resize 는 src 로부터 이미지를 읽어 리사이즈 후 dst 에 기록하고, 단계별 타이밍을 반환한다.
+	Resize(src io.Reader, dst io.Writer, p Params) (Stats, error)
+}
+
+// activeBackend 는 프로세스에서 실제로 사용할 backend. init() 에서 선택된다.
+var activeBackend Backend
+
+func init() {
+	if b := newVipsBackendIfAvailable(); b != nil {
+		activeBackend = b
+		return
+	}
+	// vips 레이어 없이 로컬 개발 / lambda 에서도 동작하도록 순수 Go 구현으로 폴백
+	activeBackend = newGoBackend()
+}
+
+// resizeParamsFromConfig 는 기존 RequestConfig 와 협상된 format/quality 로부터 Params 를 구성한다.
+func resizeParamsFromConfig(config *RequestConfig, format string, quality int) Params {
+	maxBytes := int64(defaultMaxSourceBytes)
+	if v := os.Getenv("RESIZE_MAX_SOURCE_BYTES"); v != "" {
+		if n, err := parsePositiveInt64(v); err == nil {
+			maxBytes = n
+		}
+	}
+
+	maxPixels := int64(defaultMaxPixels)
+	if v := os.Getenv("RESIZE_MAX_PIXELS"); v != "" {
+		if n, err := parsePositiveInt64(v); err == nil {
+			maxPixels = n
+		}
+	}
+
+	return Params{
+		Width:          config.Width,
+		Height:         config.Height,
+		Format:         format,
+		Quality:        quality,
+		Fit:            config.Fit,
+		Anchor:         config.Anchor,
+		Focus:          config.Focus,
+		MaxSourceBytes: maxBytes,
+		MaxPixels:      maxPixels,
+	}
+}
+
+// errSourceTooLarge 는 디코딩된 이미지가 허용된 픽셀 수를 초과했을 때 반환된다.
+var errSourceTooLarge = errors.New("source image exceeds max pixel budget")
+
+func parsePositiveInt64(s string) (int64, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, errInvalidLimit
+	}
+	return n, nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// 기본 캐시 총 용량 (바이트) - RESIZE_CACHE_BYTES 환경변수로 오버라이드 가능
+const defaultCacheBytes = 64 * 1024 * 1024
+
+// 캐시에 들어갈 수 있는 엔트리 하나의 최대 크기 (너무 큰 결과물이 캐시 전체를 잡아먹는 것을 방지)
+const maxCacheEntryBytes = 8 * 1024 * 1024
+
+// resizeCacheKey 는 (objectKey, width, height, format, quality, fit, anchor, focus, etag) 조합에
+// 대한 캐시/싱글플라이트 키. fit/anchor/focus 가 빠지면 같은 크기라도 다른 크롭 결과가 같은 캐시 엔트리를
+// 공유하게 되어, 먼저 들어간 요청의 결과가 다른 fit/focus 요청에게도 잘못 돌아가게 된다.
+type resizeCacheKey struct {
+	ObjectKey string
+	Width     int
+	Height    int
+	Format    string
+	Quality   int
+	Fit       string
+	Anchor    int
+	Focus     *focusPoint
+	ETag      string
+}
+
+func (k resizeCacheKey) String() string {
+	return fmt.Sprintf("%s|%d|%d|%s|%d|%s|%d|%s|%s",
+		k.ObjectKey, k.Width, k.Height, k.Format, k.Quality, k.Fit, k.Anchor, focusString(k.Focus), k.ETag)
+}
+
+// lruEntry 는 LRU 리스트에 보관되는 캐시 항목
+type lruEntry struct {
+	key   string
+	value []byte
+}
+
+// byteBudgetLRU 는 총 바이트 예산을 가진 size-bounded LRU 캐시
+type byteBudgetLRU struct {
+	mu        sync.Mutex
+	maxBytes  int
+	usedBytes int
+	ll        *list.List
+	items     map[string]*list.Element
+}
+
+func newByteBudgetLRU(maxBytes int) *byteBudgetLRU {
+	return &byteBudgetLRU{
+		maxBytes: maxBytes,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (c *byteBudgetLRU) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*lruEntry).value, true
+}
+
+func (c *byteBudgetLRU) set(key string, value []byte) {
+	// per-entry 최대 크기를 넘으면 캐시에 담지 않는다
+	if len(value) > maxCacheEntryBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.usedBytes -= len(el.Value.(*lruEntry).value)
+		el.Value.(*lruEntry).value = value
+		c.usedBytes += len(value)
+		c.ll.MoveToFront(el)
+		c.evictLocked()
+		return
+	}
+
+	el := c.ll.PushFront(&lruEntry{key: key, value: value})
+	c.items[key] = el
+	c.usedBytes += len(value)
+	c.evictLocked()
+}
+
+// evictLocked 는 usedBytes 가 maxBytes 를 넘지 않을 때까지 가장 오래된 항목부터 제거한다
+func (c *byteBudgetLRU) evictLocked() {
+	for c.usedBytes > c.maxBytes {
+		oldest := c.ll.Back()
+		if oldest == nil {
+			return
+		}
+		c.ll.Remove(oldest)
+		entry := oldest.Value.(*lruEntry)
+		delete(c.items, entry.key)
+		c.usedBytes -= len(entry.value)
+	}
+}
+
+// 프로세스 전역으로 공유되는 리사이즈 결과 캐시와 동시 요청 중복 제거 그룹
+var (
+	resizeCache *byteBudgetLRU
+	resizeGroup singleflight.Group
+)
+
+func init() {
+	budget := defaultCacheBytes
+	if v := os.Getenv("RESIZE_CACHE_BYTES"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			budget = parsed
+		}
+	}
+	resizeCache = newByteBudgetLRU(budget)
+}
+
+// resizeWithCache 는 같은 (objectKey, width, height, format, etag) 조합에 대해
+// 캐시에 있으면 바로 반환하고, 없으면 singleflight 로 동시 요청을 하나로 묶어 fn 을 1회만 실행한다.
+// 반환하는 bool 은 캐시 히트 여부로, 메트릭에 cache hit/miss 로 그대로 실린다.
+func resizeWithCache(key resizeCacheKey, fn func() ([]byte, error)) ([]byte, bool, error) {
+	cacheKey := key.String()
+
+	if cached, ok := resizeCache.get(cacheKey); ok {
+		return cached, true, nil
+	}
+
+	type result struct {
+		bytes []byte
+		hit   bool
+	}
+
+	v, err, _ := resizeGroup.Do(cacheKey, func() (interface{}, error) {
+		// 싱글플라이트 그룹 진입 직후 다시 한 번 확인 (먼저 들어간 요청이 이미 채웠을 수 있음)
+		if cached, ok := resizeCache.get(cacheKey); ok {
+			return result{bytes: cached, hit: true}, nil
+		}
+
+		out, err := fn()
+		if err != nil {
+			return nil, err
+		}
+
+		resizeCache.set(cacheKey, out)
+		return result{bytes: out, hit: false}, nil
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	r := v.(result)
+	return r.bytes, r.hit, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestByteBudgetLRUEvictsOldestWhenOverBudget(t *testing.T) {
+	c := newByteBudgetLRU(10)
+
+	c.set("a", []byte("12345")) // 5 bytes, usedBytes=5
+	c.set("b", []byte("12345")) // 5 bytes, usedBytes=10
+
+	if _, ok := c.get("a"); !ok {
+		t.Fatalf("expected 'a' to still be cached before budget is exceeded")
+	}
+
+	c.set("c", []byte("12345")) // pushes usedBytes to 15, must evict down to <=10
+
+	if _, ok := c.get("b"); ok {
+		t.Fatalf("expected 'b' (least recently used) to have been evicted")
+	}
+	if _, ok := c.get("a"); !ok {
+		t.Fatalf("expected 'a' (recently touched by get) to survive eviction")
+	}
+	if _, ok := c.get("c"); !ok {
+		t.Fatalf("expected newly inserted 'c' to be cached")
+	}
+}
+
+func TestByteBudgetLRURejectsEntriesOverMaxEntrySize(t *testing.T) {
+	c := newByteBudgetLRU(defaultCacheBytes)
+	oversized := make([]byte, maxCacheEntryBytes+1)
+
+	c.set("too-big", oversized)
+
+	if _, ok := c.get("too-big"); ok {
+		t.Fatalf("expected entry larger than maxCacheEntryBytes to be rejected")
+	}
+}
+
+func TestResizeWithCacheReturnsCachedBytesOnSecondCall(t *testing.T) {
+	key := resizeCacheKey{ObjectKey: "/a.png", Width: 100, Height: 100, Format: "jpeg", ETag: "etag-1"}
+
+	var calls int32
+	fn := func() ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		return []byte("resized-bytes"), nil
+	}
+
+	first, hit, err := resizeWithCache(key, fn)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hit {
+		t.Fatalf("expected first call to be a cache miss")
+	}
+
+	second, hit, err := resizeWithCache(key, fn)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hit {
+		t.Fatalf("expected second call with identical key to be a cache hit")
+	}
+	if string(second) != string(first) {
+		t.Fatalf("expected cached bytes to match original output")
+	}
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("expected fn to run exactly once, ran %d times", calls)
+	}
+}
+
+func TestResizeWithCacheSingleFlightsConcurrentMisses(t *testing.T) {
+	key := resizeCacheKey{ObjectKey: "/b.png", Width: 200, Height: 200, Format: "webp", ETag: "etag-2"}
+
+	var calls int32
+	release := make(chan struct{})
+	fn := func() ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return []byte("concurrent-bytes"), nil
+	}
+
+	const concurrency = 8
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			if _, _, err := resizeWithCache(key, fn); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+
+	close(release)
+	wg.Wait()
+
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("expected exactly one S3 GET + decode/resize for identical concurrent keys, got %d", calls)
+	}
+}
+
+func TestResizeCacheKeyDiffersByDistinguishingFields(t *testing.T) {
+	base := resizeCacheKey{ObjectKey: "/a.png", Width: 256, Height: 256, Format: "jpeg", Quality: 80, ETag: "etag"}
+
+	byFormat := base
+	byFormat.Format = "webp"
+	if base.String() == byFormat.String() {
+		t.Fatalf("expected cache keys with different formats to be distinct")
+	}
+
+	byQuality := base
+	byQuality.Quality = 60
+	if base.String() == byQuality.String() {
+		t.Fatalf("expected cache keys with different quality values to be distinct")
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"image"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// Fit 은 width/height 박스에 이미지를 맞추는 방식.
+type Fit string
+
+const (
+	// FitScale 은 기존 동작 그대로 width x height 로 늘리거나 줄인다 (비율 무시 가능).
+	FitScale Fit = "scale"
+	// FitContain 은 비율을 유지한 채 박스 안에 들어가도록 축소한다 (레터박스는 만들지 않음).
+	FitContain Fit = "contain"
+	// FitCover 는 비율을 유지한 채 박스를 가득 채우고 남는 부분을 자른다.
+	FitCover Fit = "cover"
+	// FitCrop 은 리사이즈 없이 박스 크기만큼 gravity/focus 기준으로 자른다.
+	FitCrop Fit = "crop"
+	// FitSmart 는 엔트로피(엣지 에너지)가 가장 높은 영역을 주제로 보고 그 부분을 보존하도록 자른다.
+	FitSmart Fit = "smart"
+)
+
+// parseFit 은 fit 쿼리 파라미터를 검증하고, 모르는 값이면 기존 동작과 같은 FitScale 로 떨어진다.
+func parseFit(raw string) Fit {
+	switch Fit(raw) {
+	case FitContain, FitCover, FitCrop, FitSmart:
+		return Fit(raw)
+	default:
+		return FitScale
+	}
+}
+
+// applyFit 은 fit 모드에 따라 srcImg 를 width x height 박스에 맞춰 반환한다.
+func applyFit(srcImg image.Image, width, height int, fit Fit, anchor imaging.Anchor, focus *focusPoint) image.Image {
+	switch fit {
+	case FitContain:
+		// imaging.Fit 은 width, height 둘 다 >0 이어야 하고, 하나라도 <=0 이면 0x0 이미지를 반환한다.
+		// width 또는 height 하나만 온 contain 요청(예: width=300 만으로 비율 유지 축소)은 박스에
+		// "맞추는" 게 아니라 그냥 비율을 유지한 채 스케일하는 것이므로 imaging.Resize 의 0=비율유지
+		// 관례를 그대로 따른다.
+		if width <= 0 || height <= 0 {
+			return imaging.Resize(srcImg, width, height, imaging.Lanczos)
+		}
+		return imaging.Fit(srcImg, width, height, imaging.Lanczos)
+	case FitCover:
+		if focus != nil {
+			return fillAtFocus(srcImg, width, height, *focus)
+		}
+		return imaging.Fill(srcImg, width, height, anchor, imaging.Lanczos)
+	case FitCrop:
+		if focus != nil {
+			return cropAtFocus(srcImg, width, height, *focus)
+		}
+		return imaging.CropAnchor(srcImg, width, height, anchor)
+	case FitSmart:
+		return smartCrop(srcImg, width, height)
+	default:
+		return imaging.Resize(srcImg, width, height, imaging.Lanczos)
+	}
+}
+
+// focusPoint 는 0..1 로 정규화된 관심 좌표 (focus 쿼리 파라미터).
+type focusPoint struct {
+	X, Y float64
+}
+
+// cropAtFocus 는 focus 좌표를 중심으로 width x height 크기만큼 잘라낸다.
+func cropAtFocus(srcImg image.Image, width, height int, focus focusPoint) image.Image {
+	b := srcImg.Bounds()
+	cx := b.Min.X + int(focus.X*float64(b.Dx()))
+	cy := b.Min.Y + int(focus.Y*float64(b.Dy()))
+	return imaging.Crop(srcImg, clampRect(b, cx, cy, width, height))
+}
+
+// fillAtFocus 는 focus 좌표를 보존하면서 width x height 로 스케일 후 자른다.
+func fillAtFocus(srcImg image.Image, width, height int, focus focusPoint) image.Image {
+	b := srcImg.Bounds()
+	scale := maxFloat(float64(width)/float64(b.Dx()), float64(height)/float64(b.Dy()))
+	scaledW := int(float64(b.Dx()) * scale)
+	scaledH := int(float64(b.Dy()) * scale)
+	scaled := imaging.Resize(srcImg, scaledW, scaledH, imaging.Lanczos)
+
+	cx := int(focus.X * float64(scaledW))
+	cy := int(focus.Y * float64(scaledH))
+	return imaging.Crop(scaled, clampRect(scaled.Bounds(), cx, cy, width, height))
+}
+
+// clampRect 는 (cx, cy) 를 중심으로 한 width x height 사각형을 bounds 안쪽으로 밀어넣는다.
+func clampRect(bounds image.Rectangle, cx, cy, width, height int) image.Rectangle {
+	x0 := cx - width/2
+	y0 := cy - height/2
+
+	if x0 < bounds.Min.X {
+		x0 = bounds.Min.X
+	}
+	if y0 < bounds.Min.Y {
+		y0 = bounds.Min.Y
+	}
+	if x0+width > bounds.Max.X {
+		x0 = bounds.Max.X - width
+	}
+	if y0+height > bounds.Max.Y {
+		y0 = bounds.Max.Y - height
+	}
+
+	return image.Rect(x0, y0, x0+width, y0+height)
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// smartCrop 은 다운샘플된 소스에서 엣지 에너지가 가장 높은 width x height 윈도우를 찾아
+// 그 영역을 원본 해상도에서 잘라내고 필요하면 스케일한다. 중앙 크롭보다 피사체를 보존하기 좋다.
+func smartCrop(srcImg image.Image, width, height int) image.Image {
+	b := srcImg.Bounds()
+
+	// 비율을 맞추기 위해 먼저 "cover" 스케일을 적용한 뒤, 그 위에서 에너지가 높은 윈도우를 고른다.
+	scale := maxFloat(float64(width)/float64(b.Dx()), float64(height)/float64(b.Dy()))
+	scaledW := int(float64(b.Dx()) * scale)
+	scaledH := int(float64(b.Dy()) * scale)
+	scaled := imaging.Resize(srcImg, scaledW, scaledH, imaging.Lanczos)
+
+	// 에너지 맵은 비용을 줄이기 위해 더 작은 해상도에서 계산한다.
+	const energyMaxDim = 128
+	downScale := 1.0
+	if scaledW > energyMaxDim || scaledH > energyMaxDim {
+		downScale = maxFloat(float64(scaledW)/energyMaxDim, float64(scaledH)/energyMaxDim)
+	}
+	energyImg := imaging.Resize(scaled, int(float64(scaledW)/downScale), int(float64(scaledH)/downScale), imaging.Lanczos)
+
+	windowW := int(float64(width) / downScale)
+	windowH := int(float64(height) / downScale)
+	ex, ey := bestEnergyWindow(energyImg, windowW, windowH)
+
+	cx := int(float64(ex+windowW/2) * downScale)
+	cy := int(float64(ey+windowH/2) * downScale)
+
+	return imaging.Crop(scaled, clampRect(scaled.Bounds(), cx, cy, width, height))
+}
+
+// bestEnergyWindow 는 grayscale 엣지 에너지(인접 픽셀 차의 절대값 합)가 가장 큰
+// windowW x windowH 윈도우의 좌상단 좌표를 찾는다. 격자 단위로만 훑어 비용을 낮춘다.
+func bestEnergyWindow(img image.Image, windowW, windowH int) (int, int) {
+	b := img.Bounds()
+	gray := imaging.Grayscale(img)
+
+	if windowW >= b.Dx() && windowH >= b.Dy() {
+		return b.Min.X, b.Min.Y
+	}
+	if windowW > b.Dx() {
+		windowW = b.Dx()
+	}
+	if windowH > b.Dy() {
+		windowH = b.Dy()
+	}
+
+	const step = 8
+	bestX, bestY := b.Min.X, b.Min.Y
+	bestEnergy := -1.0
+
+	for y := b.Min.Y; y+windowH <= b.Max.Y; y += step {
+		for x := b.Min.X; x+windowW <= b.Max.X; x += step {
+			e := windowEnergy(gray, x, y, windowW, windowH)
+			if e > bestEnergy {
+				bestEnergy = e
+				bestX, bestY = x, y
+			}
+		}
+	}
+
+	return bestX, bestY
+}
+
+// windowEnergy 는 (x, y, w, h) 영역에 대한 에지 에너지(인접 픽셀 밝기 차의 절대값 합)를 계산한다.
+func windowEnergy(gray image.Image, x, y, w, h int) float64 {
+	var energy float64
+	for j := y; j < y+h-1; j++ {
+		for i := x; i < x+w-1; i++ {
+			r1, g1, b1, _ := gray.At(i, j).RGBA()
+			r2, g2, b2, _ := gray.At(i+1, j).RGBA()
+			r3, g3, b3, _ := gray.At(i, j+1).RGBA()
+
+			l1 := float64(r1 + g1 + b1)
+			l2 := float64(r2 + g2 + b2)
+			l3 := float64(r3 + g3 + b3)
+
+			energy += absFloat(l1-l2) + absFloat(l1-l3)
+		}
+	}
+	return energy
+}
+
+func absFloat(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// normalizeDimensions 는 cover/crop/smart 처럼 width, height 가 모두 필요한 모드에서
+// 둘 중 하나만 주어졌을 때 누락된 쪽을 나머지 값으로 채워 정사각형 박스를 만든다
+// (예: width=256 만 준 프로필 아바타 요청이 256x256 cover 크롭이 되도록).
+func normalizeDimensions(width, height int, fit Fit) (int, int) {
+	if fit != FitCover && fit != FitCrop && fit != FitSmart {
+		return width, height
+	}
+	if width <= 0 {
+		return height, height
+	}
+	if height <= 0 {
+		return width, width
+	}
+	return width, height
+}
+
+// parseFocus 는 "x,y" (0..1 정규화된 좌표) 형식의 focus 쿼리 파라미터를 파싱한다.
+// 형식이 올바르지 않거나 비어 있으면 nil 을 반환해 gravity 기반 anchor 로 대체하게 한다.
+func parseFocus(raw string) *focusPoint {
+	parts := strings.SplitN(raw, ",", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+
+	x, errX := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	y, errY := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if errX != nil || errY != nil || x < 0 || x > 1 || y < 0 || y > 1 {
+		return nil
+	}
+
+	return &focusPoint{X: x, Y: y}
+}
+
+// anchorFromGravity 는 gravity 쿼리 파라미터("north", "southeast" 등)를 imaging.Anchor 로 변환한다.
+func anchorFromGravity(raw string) imaging.Anchor {
+	switch raw {
+	case "north":
+		return imaging.Top
+	case "south":
+		return imaging.Bottom
+	case "east":
+		return imaging.Right
+	case "west":
+		return imaging.Left
+	case "northeast":
+		return imaging.TopRight
+	case "northwest":
+		return imaging.TopLeft
+	case "southeast":
+		return imaging.BottomRight
+	case "southwest":
+		return imaging.BottomLeft
+	default:
+		return imaging.Center
+	}
+}
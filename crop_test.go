@@ -0,0 +1,130 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+func TestNormalizeDimensionsSquaresUpMissingSideForCoverCropSmart(t *testing.T) {
+	for _, fit := range []Fit{FitCover, FitCrop, FitSmart} {
+		if w, h := normalizeDimensions(256, 0, fit); w != 256 || h != 256 {
+			t.Errorf("fit=%s: normalizeDimensions(256, 0) = (%d, %d), want (256, 256)", fit, w, h)
+		}
+		if w, h := normalizeDimensions(0, 256, fit); w != 256 || h != 256 {
+			t.Errorf("fit=%s: normalizeDimensions(0, 256) = (%d, %d), want (256, 256)", fit, w, h)
+		}
+	}
+}
+
+func TestNormalizeDimensionsLeavesScaleAndContainUntouched(t *testing.T) {
+	for _, fit := range []Fit{FitScale, FitContain} {
+		if w, h := normalizeDimensions(300, 0, fit); w != 300 || h != 0 {
+			t.Errorf("fit=%s: normalizeDimensions(300, 0) = (%d, %d), want (300, 0)", fit, w, h)
+		}
+	}
+}
+
+func TestApplyFitContainPreservesAspectRatioWhenOneDimensionMissing(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 400, 200))
+	out := applyFit(src, 300, 0, FitContain, imaging.Center, nil)
+
+	b := out.Bounds()
+	if b.Dx() <= 0 || b.Dy() <= 0 {
+		t.Fatalf("applyFit(contain, 300, 0) produced an empty image: %dx%d", b.Dx(), b.Dy())
+	}
+	if b.Dx() != 300 {
+		t.Errorf("expected width 300, got %d", b.Dx())
+	}
+	if b.Dy() != 150 {
+		t.Errorf("expected height scaled to preserve 2:1 aspect ratio (150), got %d", b.Dy())
+	}
+}
+
+func TestClampRectKeepsRectWithinBounds(t *testing.T) {
+	bounds := image.Rect(0, 0, 100, 100)
+
+	cases := []struct {
+		name   string
+		cx, cy int
+		w, h   int
+		wantX0 int
+		wantY0 int
+	}{
+		{"centered", 50, 50, 20, 20, 40, 40},
+		{"clamped top-left", 0, 0, 20, 20, 0, 0},
+		{"clamped bottom-right", 100, 100, 20, 20, 80, 80},
+	}
+
+	for _, c := range cases {
+		r := clampRect(bounds, c.cx, c.cy, c.w, c.h)
+		if r.Min.X != c.wantX0 || r.Min.Y != c.wantY0 {
+			t.Errorf("%s: clampRect() = %v, want origin (%d, %d)", c.name, r, c.wantX0, c.wantY0)
+		}
+		if !r.In(bounds) {
+			t.Errorf("%s: clampRect() = %v is not within bounds %v", c.name, r, bounds)
+		}
+		if r.Dx() != c.w || r.Dy() != c.h {
+			t.Errorf("%s: clampRect() size = %dx%d, want %dx%d", c.name, r.Dx(), r.Dy(), c.w, c.h)
+		}
+	}
+}
+
+func TestParseFocusParsesValidNormalizedCoords(t *testing.T) {
+	f := parseFocus("0.2,0.8")
+	if f == nil {
+		t.Fatal("expected non-nil focusPoint")
+	}
+	if f.X != 0.2 || f.Y != 0.8 {
+		t.Errorf("parseFocus(\"0.2,0.8\") = %+v, want {0.2 0.8}", f)
+	}
+}
+
+func TestParseFocusRejectsOutOfRangeAndMalformedInput(t *testing.T) {
+	for _, raw := range []string{"", "1.5,0.5", "-0.1,0.5", "0.5", "a,b", "0.5,0.5,0.5"} {
+		if f := parseFocus(raw); f != nil {
+			t.Errorf("parseFocus(%q) = %+v, want nil", raw, f)
+		}
+	}
+}
+
+// smartCrop 은 엣지 에너지가 가장 높은 영역(체스판 무늬 쪽)을 단색 배경보다 우선해야 한다.
+func TestSmartCropPrefersHighEnergyRegionOverFlatBackground(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 200, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			src.Set(x, y, color.White)
+		}
+	}
+	// 오른쪽 절반에 체크무늬(고주파 에너지)를 그려 넣는다.
+	for y := 0; y < 100; y++ {
+		for x := 100; x < 200; x++ {
+			if (x+y)%2 == 0 {
+				src.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	out := smartCrop(src, 80, 80)
+	b := out.Bounds()
+	if b.Dx() != 80 || b.Dy() != 80 {
+		t.Fatalf("smartCrop() size = %dx%d, want 80x80", b.Dx(), b.Dy())
+	}
+
+	// Lanczos 리샘플링을 거치면 순수 흑백이 아니라 회색조 섞임이 생기므로, 완전한 흰색(0xffff)이
+	// 아닌 픽셀이 있는지만 확인해 체크무늬 영역이 크롭에 포함됐는지 본다.
+	var nonWhiteCount int
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := out.At(x, y).RGBA()
+			if r != 0xffff || g != 0xffff || bl != 0xffff {
+				nonWhiteCount++
+			}
+		}
+	}
+	if nonWhiteCount == 0 {
+		t.Error("expected smartCrop to include part of the high-energy checkerboard region, got none")
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"image"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/chai2010/webp"
+	"github.com/disintegration/imaging"
+)
+
+// defaultQuality 는 quality 쿼리 파라미터가 없을 때 사용하는 기본 인코딩 품질 (0-100)
+const defaultQuality = 82
+
+// supportedFormats 는 format 쿼리 파라미터 / Accept 협상에서 허용하는 포맷과 우선순위 순서.
+// 앞쪽일수록 동일 q-value 에서 우선한다.
+var supportedFormats = []string{"avif", "webp", "jpeg", "png"}
+
+// mimeByFormat 은 포맷 이름을 실제 응답 Content-Type 으로 매핑한다.
+var mimeByFormat = map[string]string{
+	"jpeg": "image/jpeg",
+	"png":  "image/png",
+	"webp": "image/webp",
+	"avif": "image/avif",
+}
+
+// formatByMime 은 Accept 헤더에 담긴 MIME 타입을 내부 포맷 이름으로 되돌린다.
+var formatByMime = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/webp": "webp",
+	"image/avif": "avif",
+}
+
+// encodeFunc 는 포맷별 인코더 시그니처. quality 는 jpeg/webp/avif 에서만 의미가 있다.
+type encodeFunc func(dst io.Writer, img image.Image, quality int) error
+
+// encoderRegistry 는 MIME 타입이 아닌 내부 포맷 이름으로 키를 둔 인코더 레지스트리.
+// Content-Type 과의 변환은 mimeByFormat 을 거친다.
+var encoderRegistry = map[string]encodeFunc{
+	"jpeg": func(dst io.Writer, img image.Image, quality int) error {
+		return imaging.Encode(dst, img, imaging.JPEG, imaging.JPEGQuality(quality))
+	},
+	"png": func(dst io.Writer, img image.Image, _ int) error {
+		return imaging.Encode(dst, img, imaging.PNG)
+	},
+	"webp": func(dst io.Writer, img image.Image, quality int) error {
+		return webp.Encode(dst, img, &webp.Options{Quality: float32(quality)})
+	},
+}
+
+// registerAvifEncoder 는 avif 빌드 태그가 켜진 바이너리에서만 encoderRegistry 에 "avif" 를 채운다
+// (avif_encoder.go). 태그가 없으면 avif_stub.go 의 no-op 버전이 쓰여 encoderRegistry 에
+// "avif" 가 아예 없고, negotiateFormat/encodeByFormat 은 자동으로 다른 포맷으로 떨어진다.
+func init() {
+	registerAvifEncoder()
+}
+
+// encodeByFormat 은 format 이름(레지스트리에 없으면 jpeg)으로 img 를 인코딩한다.
+func encodeByFormat(dst io.Writer, img image.Image, format string, quality int) error {
+	enc, ok := encoderRegistry[format]
+	if !ok {
+		enc = encoderRegistry["jpeg"]
+	}
+	return enc(dst, img, quality)
+}
+
+// negotiateFormat 은 `format` 쿼리 파라미터를 최우선으로 쓰고, 없으면 Accept 헤더를 파싱해
+// 클라이언트가 지원한다고 밝힌 것 중 supportedFormats 우선순위가 가장 높은 포맷을 고른다.
+// 아무것도 식별할 수 없으면 기존 동작과 같이 jpeg 로 떨어진다.
+func negotiateFormat(acceptHeader, formatParam string) string {
+	formatParam = strings.ToLower(strings.TrimSpace(formatParam))
+	if _, ok := encoderRegistry[formatParam]; ok {
+		return formatParam
+	}
+
+	accepted := parseAccept(acceptHeader)
+	for _, f := range supportedFormats {
+		if _, ok := encoderRegistry[f]; !ok {
+			// 이 빌드에 인코더가 없는 포맷(예: avif 빌드 태그 없이 빌드된 경우)은 건너뛴다
+			continue
+		}
+		if accepted[mimeByFormat[f]] || accepted["*/*"] || accepted["image/*"] {
+			return f
+		}
+	}
+
+	return "jpeg"
+}
+
+// parseAccept 는 "image/webp,image/avif;q=0.9,*/*;q=0.5" 같은 Accept 헤더를
+// 품질값을 무시하고 등장한 MIME 타입 집합으로 단순화한다 - 여기서는 지원 여부만 필요하기 때문.
+func parseAccept(header string) map[string]bool {
+	result := make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		mime := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		if mime != "" {
+			result[mime] = true
+		}
+	}
+	return result
+}
+
+// parseQuality 는 quality 쿼리 파라미터를 0-100 범위로 정규화한다.
+func parseQuality(raw string) int {
+	q, err := strconv.Atoi(raw)
+	if err != nil || q <= 0 {
+		return defaultQuality
+	}
+	if q > 100 {
+		return 100
+	}
+	return q
+}
@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestNegotiateFormatPrefersExplicitFormatParam(t *testing.T) {
+	got := negotiateFormat("image/jpeg", "webp")
+	if got != "webp" {
+		t.Fatalf("expected explicit format param to win, got %q", got)
+	}
+}
+
+func TestNegotiateFormatIgnoresUnknownFormatParam(t *testing.T) {
+	got := negotiateFormat("image/webp", "not-a-format")
+	if got != "webp" {
+		t.Fatalf("expected fallback to Accept header negotiation, got %q", got)
+	}
+}
+
+func TestNegotiateFormatFallsBackToJPEGByDefault(t *testing.T) {
+	got := negotiateFormat("", "")
+	if got != "jpeg" {
+		t.Fatalf("expected jpeg fallback when nothing is negotiable, got %q", got)
+	}
+}
+
+func TestNegotiateFormatNeverPicksAFormatWithoutARegisteredEncoder(t *testing.T) {
+	got := negotiateFormat("image/avif,image/webp;q=0.9", "")
+	if _, ok := encoderRegistry[got]; !ok {
+		t.Fatalf("negotiateFormat returned %q which has no registered encoder in this build", got)
+	}
+}
+
+func TestParseQualityClampsAndDefaults(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want int
+	}{
+		{"", defaultQuality},
+		{"not-a-number", defaultQuality},
+		{"0", defaultQuality},
+		{"-5", defaultQuality},
+		{"50", 50},
+		{"1000", 100},
+	}
+
+	for _, c := range cases {
+		if got := parseQuality(c.raw); got != c.want {
+			t.Errorf("parseQuality(%q) = %d, want %d", c.raw, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io"
+	"time"
+
+	"github.com/disintegration/imaging"
+)
+
+// goBackend 는 disintegration/imaging 에 기반한 순수 Go 리사이즈 구현.
+// libvips 레이어(vips 빌드 태그)가 없는 환경에서 항상 동작하는 기본 backend.
+type goBackend struct{}
+
+func newGoBackend() Backend {
+	return goBackend{}
+}
+
+func (goBackend) Name() string {
+	return "go"
+}
+
+func (goBackend) Resize(src io.Reader, dst io.Writer, p Params) (Stats, error) {
+	var stats Stats
+
+	counting := &countingReader{r: io.LimitReader(src, p.MaxSourceBytes+1)}
+
+	decodeStart := time.Now()
+	// AutoOrientation 은 EXIF Orientation 태그를 읽어 회전/반전을 적용한다 -
+	// 이게 없으면 세로로 찍은 폰 사진이 옆으로 누운 채로 나간다.
+	srcImg, err := imaging.Decode(counting, imaging.AutoOrientation(true))
+	stats.DecodeDuration = time.Since(decodeStart)
+	stats.SourceBytes = counting.n
+	// LimitReader 로 MaxSourceBytes+1 까지만 읽게 해뒀으므로, 실제로 그 이상을 읽었으면 상한을
+	// 넘은 것 - 디코더가 우연히 에러 없이 끝나더라도(혹은 모호한 디코드 에러를 내더라도) vips
+	// backend 와 동일하게 errSourceTooLarge 로 명확히 보고한다.
+	if stats.SourceBytes > p.MaxSourceBytes {
+		return stats, errSourceTooLarge
+	}
+	if err != nil {
+		return stats, err
+	}
+
+	b := srcImg.Bounds()
+	stats.DecodedPixels = int64(b.Dx()) * int64(b.Dy())
+	if stats.DecodedPixels > p.MaxPixels {
+		return stats, errSourceTooLarge
+	}
+
+	// scale 모드에서 리사이징 넓이가 현재 이미지 넓이보다 크거나 같으면 리사이즈를 건너뛰고 원본을 그대로 인코딩
+	outImg := srcImg
+	if p.Fit != FitScale || b.Max.X > p.Width {
+		resizeStart := time.Now()
+		outImg = applyFit(srcImg, p.Width, p.Height, p.Fit, p.Anchor, p.Focus)
+		stats.ResizeDuration = time.Since(resizeStart)
+	}
+
+	counter := &countingWriter{w: dst}
+	encodeStart := time.Now()
+	err = encodeByFormat(counter, outImg, p.Format, p.Quality)
+	stats.EncodeDuration = time.Since(encodeStart)
+	stats.OutputBytes = counter.n
+
+	return stats, err
+}
+
+// countingReader 는 decode 동안 실제로 읽은 소스 바이트 수를 센다.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// countingWriter 는 encode 동안 실제로 쓴 출력 바이트 수를 센다.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+func encodeTestJPEG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x % 256), G: uint8(y % 256), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, img, imaging.JPEG); err != nil {
+		t.Fatalf("failed to encode test jpeg: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestGoBackendResizeRejectsSourceOverMaxSourceBytes(t *testing.T) {
+	data := encodeTestJPEG(t, 200, 200)
+
+	p := Params{
+		Width:          50,
+		Height:         50,
+		Format:         "jpeg",
+		Quality:        80,
+		Fit:            FitScale,
+		MaxSourceBytes: int64(len(data) / 2),
+		MaxPixels:      defaultMaxPixels,
+	}
+
+	var dst bytes.Buffer
+	_, err := goBackend{}.Resize(bytes.NewReader(data), &dst, p)
+	if !errors.Is(err, errSourceTooLarge) {
+		t.Fatalf("expected errSourceTooLarge for a source over MaxSourceBytes, got %v", err)
+	}
+}
+
+func TestGoBackendResizeSucceedsWithinMaxSourceBytes(t *testing.T) {
+	data := encodeTestJPEG(t, 200, 200)
+
+	p := Params{
+		Width:          50,
+		Height:         50,
+		Format:         "jpeg",
+		Quality:        80,
+		Fit:            FitScale,
+		MaxSourceBytes: int64(len(data) * 2),
+		MaxPixels:      defaultMaxPixels,
+	}
+
+	var dst bytes.Buffer
+	stats, err := goBackend{}.Resize(bytes.NewReader(data), &dst, p)
+	if err != nil {
+		t.Fatalf("expected resize within MaxSourceBytes to succeed, got %v", err)
+	}
+	if dst.Len() == 0 {
+		t.Error("expected encoded output bytes, got none")
+	}
+	if stats.SourceBytes != int64(len(data)) {
+		t.Errorf("stats.SourceBytes = %d, want %d", stats.SourceBytes, len(data))
+	}
+}
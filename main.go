@@ -15,6 +15,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type RequestConfig struct {
@@ -22,6 +23,9 @@ type RequestConfig struct {
 	ObjectKey string
 	Width     int
 	Height    int
+	Fit       Fit
+	Anchor    imaging.Anchor
+	Focus     *focusPoint
 }
 
 type JSON map[string]interface{}
@@ -50,39 +54,33 @@ func errResponse(code int) events.APIGatewayProxyResponse {
 	}
 }
 
-func resizer(req io.Reader, config *RequestConfig) (string, error) {
-	// 네트워크 요청으로 받은 파일을 데이터로 읽는다.
-	srcImg, err := imaging.Decode(req)
+// resizer 는 선택된 activeBackend (vips 가능하면 vips, 아니면 순수 Go) 로 리사이즈를 위임한다.
+// src 는 스트리밍으로 전달되며, backend 가 자체적으로 MaxSourceBytes/MaxPixels 상한을 적용한다.
+func resizer(req io.Reader, config *RequestConfig, format string, quality int) ([]byte, Stats, error) {
+	var buf bytes.Buffer
+	stats, err := activeBackend.Resize(req, &buf, resizeParamsFromConfig(config, format, quality))
 	if err != nil {
-		return "", err
+		return nil, stats, err
 	}
+	return buf.Bytes(), stats, nil
+}
 
-	// 현재 리사이징 하려는 이미지의 넓이 / 높이값
-	b := srcImg.Bounds()
-	// 리사이징 넓이가 현재 이미지 넓이보다 크거나 같으면 원본을 리턴
-	if b.Max.X <= config.Width {
-		var buf bytes.Buffer
-		// 현재 이미지를 JPEG 로만 변경하고 넘겨준다.
-		if err := imaging.Encode(&buf, srcImg, imaging.JPEG); err != nil {
-			return "", err
+func handler(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	start := time.Now()
+	correlationID := req.RequestContext.RequestID
+
+	// 디버그 엔드포인트 - RESIZE_DEBUG_ENABLED=true 일 때만 최근 요청 타이밍을 돌려준다
+	if req.Path == debugTimingsPath {
+		if body, ok := debugTimingsResponse(); ok {
+			return events.APIGatewayProxyResponse{
+				Body:       string(body),
+				Headers:    map[string]string{"Content-Type": "application/json"},
+				StatusCode: 200,
+			}, nil
 		}
-
-		return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+		return errResponse(http.StatusNotFound), nil
 	}
 
-	// Resize srcImage to size = width (px) x height (px) using the Lanczos filter.
-	dstImg := imaging.Resize(srcImg, config.Width, config.Height, imaging.Lanczos)
-
-	var buf bytes.Buffer
-	// encode 이미지를 지정된 형식 (JPEG, PNG, GIF, TIFF 또는 BMP)으로 변경
-	if err := imaging.Encode(&buf, dstImg, imaging.JPEG); err != nil {
-		return "", err
-	}
-
-	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
-}
-
-func handler(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	width, _ := strconv.Atoi(req.QueryStringParameters["width"])
 	height, _ := strconv.Atoi(req.QueryStringParameters["height"])
 	objectKey := req.Path
@@ -97,15 +95,37 @@ func handler(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse,
 		return errResponse(http.StatusBadRequest), errors.WithMessage(nil, "WIDTH AND HEIGHT INVALID DATA")
 	}
 
+	// fit 모드 + gravity/focus - scale(기본) 외에 contain/cover/crop/smart 를 지원한다
+	fit := parseFit(req.QueryStringParameters["fit"])
+	focus := parseFocus(req.QueryStringParameters["focus"])
+	anchor := anchorFromGravity(req.QueryStringParameters["gravity"])
+
+	// cover/crop/smart 는 width, height 가 모두 필요 - 하나만 왔으면 정사각형으로 채워
+	// (예: width 하나만 받아도 정사각형 아바타를 만들 수 있도록) 준다.
+	width, height = normalizeDimensions(width, height, fit)
+
 	config := RequestConfig{
 		Width:     width,
 		Height:    height,
 		Bucket:    bucketName,
 		ObjectKey: objectKey,
+		Fit:       fit,
+		Anchor:    anchor,
+		Focus:     focus,
 	}
 
-	// s3에서 데이터를 가져온다
-	resp, err := svc.GetObject(&s3.GetObjectInput{
+	// format/quality 협상 - format 쿼리 파라미터가 우선이고, 없으면 Accept 헤더를 본다
+	format := negotiateFormat(req.Headers["Accept"], req.QueryStringParameters["format"])
+	quality := parseQuality(req.QueryStringParameters["quality"])
+
+	// 서명된 요청이거나 허가된 preset 크기가 아니면 거부 - 임의 크기 조합으로 비용/캐시를 터뜨리는 것을 방지
+	if err := authorizeResize(req.QueryStringParameters, &config, format, quality); err != nil {
+		return errResponse(http.StatusForbidden), errors.WithMessage(err, "FORBIDDEN")
+	}
+
+	// 캐시 키를 만드는 데 필요한 ETag/메타데이터만 HeadObject 로 가볍게 확인한다 -
+	// 캐시 히트라면 이 아래에서 전체 바이트를 내려받는 GetObject 는 아예 호출하지 않는다.
+	head, err := svc.HeadObject(&s3.HeadObjectInput{
 		Bucket: aws.String(config.Bucket),
 		Key:    aws.String(config.ObjectKey),
 	})
@@ -115,7 +135,7 @@ func handler(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse,
 			switch awsErr.Code() {
 			case s3.ErrCodeNoSuchBucket:
 				fallthrough
-			case s3.ErrCodeNoSuchKey:
+			case s3.ErrCodeNoSuchKey, "NotFound":
 				return errResponse(http.StatusNotFound), nil
 			}
 		}
@@ -123,21 +143,85 @@ func handler(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse,
 		return errResponse(http.StatusInternalServerError), errors.WithMessage(err, "WIDTH AND HEIGHT INVALID DATA")
 	}
 
-	defer resp.Body.Close()
+	// S3 ETag 를 캐시 검증 키로 사용 - 원본이 바뀌면 기존 캐시 엔트리는 자동으로 쓸모없어진다
+	etag := ""
+	if head.ETag != nil {
+		etag = *head.ETag
+	}
+
+	cacheKey := resizeCacheKey{
+		ObjectKey: config.ObjectKey,
+		Width:     config.Width,
+		Height:    config.Height,
+		Format:    format,
+		Quality:   quality,
+		Fit:       string(config.Fit),
+		Anchor:    int(config.Anchor),
+		Focus:     config.Focus,
+		ETag:      etag,
+	}
+
+	// 같은 캐시 키로 동시에 여러 요청이 들어와도 S3 GET + decode/resize 는 한 번만 수행되도록
+	// 캐시와 singleflight 로 감싼다. 캐시에 이미 있으면 GetObject 자체를 호출하지 않는다.
+	var stats Stats
+	resize, cacheHit, err := resizeWithCache(cacheKey, func() ([]byte, error) {
+		resp, err := svc.GetObject(&s3.GetObjectInput{
+			Bucket: aws.String(config.Bucket),
+			Key:    aws.String(config.ObjectKey),
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		out, s, err := resizer(resp.Body, &config, format, quality)
+		stats = s
+		return out, err
+	})
+
+	metrics := requestMetrics{
+		CorrelationID: correlationID,
+		ObjectKey:     config.ObjectKey,
+		Backend:       activeBackend.Name(),
+		Format:        format,
+		CacheHit:      cacheHit,
+		SourceBytes:   stats.SourceBytes,
+		DecodedPixels: stats.DecodedPixels,
+		OutputBytes:   stats.OutputBytes,
+		DecodeMs:      stats.DecodeDuration.Seconds() * 1000,
+		ResizeMs:      stats.ResizeDuration.Seconds() * 1000,
+		EncodeMs:      stats.EncodeDuration.Seconds() * 1000,
+		TotalMs:       time.Since(start).Seconds() * 1000,
+		At:            start,
+	}
 
-	// 라사이징 데이터
-	resize, err := resizer(resp.Body, &config)
 	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok {
+			switch awsErr.Code() {
+			case s3.ErrCodeNoSuchBucket:
+				fallthrough
+			case s3.ErrCodeNoSuchKey:
+				metrics.ErrorClass = "NOT_FOUND"
+				recordRequestMetrics(metrics)
+				return errResponse(http.StatusNotFound), nil
+			}
+		}
+
+		metrics.ErrorClass = "RESIZE_PARSING_ERROR"
+		recordRequestMetrics(metrics)
 		return errResponse(http.StatusInternalServerError), errors.WithMessage(err, "RESIZE PARSING ERROR")
 	}
 
+	recordRequestMetrics(metrics)
+
 	return events.APIGatewayProxyResponse{
-		Body: resize,
+		Body: base64.StdEncoding.EncodeToString(resize),
 		Headers: map[string]string{
-			"Content-Type":  "image/jpeg",
-			"Cache-Control": *resp.CacheControl,
-			"Last-Modified": resp.LastModified.Format(http.TimeFormat),
-			"ETag":          *resp.ETag,
+			"Content-Type":  mimeByFormat[format],
+			"Cache-Control": aws.StringValue(head.CacheControl),
+			"Last-Modified": head.LastModified.Format(http.TimeFormat),
+			"ETag":          etag,
+			"Vary":          "Accept",
 		},
 		StatusCode:      200,
 		IsBase64Encoded: true,
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// debugTimingsEnv 가 "true" 일 때만 /_debug/timings 엔드포인트가 응답한다.
+const debugTimingsEnv = "RESIZE_DEBUG_ENABLED"
+
+// debugTimingsPath 는 요청 처리 대신 최근 타이밍을 반환하는 디버그 엔드포인트 경로.
+const debugTimingsPath = "/_debug/timings"
+
+// debugRingSize 는 디버그 엔드포인트가 보관하는 최근 요청 타이밍 개수.
+const debugRingSize = 50
+
+// requestMetrics 는 한 번의 handler 호출에 대해 모아서 EMF/로그로 내보낼 정보.
+type requestMetrics struct {
+	CorrelationID string    `json:"correlationId"`
+	ObjectKey     string    `json:"objectKey"`
+	Backend       string    `json:"backend"`
+	Format        string    `json:"format"`
+	CacheHit      bool      `json:"cacheHit"`
+	ErrorClass    string    `json:"errorClass,omitempty"`
+	SourceBytes   int64     `json:"sourceBytes"`
+	DecodedPixels int64     `json:"decodedPixels"`
+	OutputBytes   int64     `json:"outputBytes"`
+	DecodeMs      float64   `json:"decodeMs"`
+	ResizeMs      float64   `json:"resizeMs"`
+	EncodeMs      float64   `json:"encodeMs"`
+	TotalMs       float64   `json:"totalMs"`
+	At            time.Time `json:"at"`
+}
+
+var (
+	debugRingMu sync.Mutex
+	debugRing   []requestMetrics
+)
+
+// recordRequestMetrics 는 EMF 형식의 메트릭 라인과 상관 관계 ID 를 포함한 구조화된 JSON 로그를
+// stdout 에 내보낸다. Lambda 에서는 stdout 으로 나간 EMF 라인을 CloudWatch 가 그대로 메트릭으로 집계한다.
+func recordRequestMetrics(m requestMetrics) {
+	emitEMF(m)
+	logStructured(m)
+
+	if os.Getenv(debugTimingsEnv) == "true" {
+		debugRingMu.Lock()
+		debugRing = append(debugRing, m)
+		if len(debugRing) > debugRingSize {
+			debugRing = debugRing[len(debugRing)-debugRingSize:]
+		}
+		debugRingMu.Unlock()
+	}
+}
+
+// emitEMF 는 CloudWatch Embedded Metric Format 라인 하나를 stdout 에 출력한다.
+func emitEMF(m requestMetrics) {
+	doc := map[string]interface{}{
+		"_aws": map[string]interface{}{
+			"Timestamp": m.At.UnixMilli(),
+			"CloudWatchMetrics": []map[string]interface{}{
+				{
+					"Namespace": "velog/resize-image",
+					"Dimensions": [][]string{
+						{"Backend", "Format", "CacheHit"},
+					},
+					"Metrics": []map[string]string{
+						{"Name": "SourceBytes", "Unit": "Bytes"},
+						{"Name": "DecodedPixels", "Unit": "Count"},
+						{"Name": "OutputBytes", "Unit": "Bytes"},
+						{"Name": "DecodeMs", "Unit": "Milliseconds"},
+						{"Name": "ResizeMs", "Unit": "Milliseconds"},
+						{"Name": "EncodeMs", "Unit": "Milliseconds"},
+						{"Name": "TotalMs", "Unit": "Milliseconds"},
+					},
+				},
+			},
+		},
+		"Backend":       m.Backend,
+		"Format":        m.Format,
+		"CacheHit":      fmt.Sprintf("%t", m.CacheHit),
+		"SourceBytes":   m.SourceBytes,
+		"DecodedPixels": m.DecodedPixels,
+		"OutputBytes":   m.OutputBytes,
+		"DecodeMs":      m.DecodeMs,
+		"ResizeMs":      m.ResizeMs,
+		"EncodeMs":      m.EncodeMs,
+		"TotalMs":       m.TotalMs,
+	}
+
+	line, err := json.Marshal(doc)
+	if err != nil {
+		return
+	}
+	fmt.Println(string(line))
+}
+
+// logStructured 는 상관 관계 ID 를 포함한 구조화된 JSON 로그 한 줄을 stdout 에 출력한다.
+func logStructured(m requestMetrics) {
+	line, err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+	fmt.Println(string(line))
+}
+
+// debugTimingsResponse 는 /_debug/timings 가 env 로 활성화되어 있을 때 최근 타이밍을 JSON 으로 반환한다.
+func debugTimingsResponse() ([]byte, bool) {
+	if os.Getenv(debugTimingsEnv) != "true" {
+		return nil, false
+	}
+
+	debugRingMu.Lock()
+	defer debugRingMu.Unlock()
+
+	body, err := json.Marshal(debugRing)
+	if err != nil {
+		return nil, false
+	}
+	return body, true
+}
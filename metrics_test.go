@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+)
+
+func withDebugTimingsEnabled(t *testing.T, enabled bool) {
+	t.Helper()
+	old := os.Getenv(debugTimingsEnv)
+	if enabled {
+		os.Setenv(debugTimingsEnv, "true")
+	} else {
+		os.Setenv(debugTimingsEnv, "")
+	}
+	t.Cleanup(func() { os.Setenv(debugTimingsEnv, old) })
+}
+
+func resetDebugRing(t *testing.T) {
+	t.Helper()
+	debugRingMu.Lock()
+	debugRing = nil
+	debugRingMu.Unlock()
+	t.Cleanup(func() {
+		debugRingMu.Lock()
+		debugRing = nil
+		debugRingMu.Unlock()
+	})
+}
+
+// captureStdout 은 fn 실행 동안 stdout 으로 나가는 출력을 캡처해 반환한다.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	old := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	os.Stdout = w
+
+	fn()
+
+	w.Close()
+	os.Stdout = old
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to read captured stdout: %v", err)
+	}
+	return string(out)
+}
+
+func TestRecordRequestMetricsGrowsDebugRingOnlyWhenEnabled(t *testing.T) {
+	resetDebugRing(t)
+	withDebugTimingsEnabled(t, false)
+
+	captureStdout(t, func() {
+		recordRequestMetrics(requestMetrics{ObjectKey: "/a.png"})
+	})
+
+	if _, ok := debugTimingsResponse(); ok {
+		t.Fatalf("expected debugTimingsResponse to report disabled when %s is not \"true\"", debugTimingsEnv)
+	}
+}
+
+func TestDebugRingCapsAtDebugRingSize(t *testing.T) {
+	resetDebugRing(t)
+	withDebugTimingsEnabled(t, true)
+
+	const total = debugRingSize + 10
+	captureStdout(t, func() {
+		for i := 0; i < total; i++ {
+			recordRequestMetrics(requestMetrics{ObjectKey: "/a.png"})
+		}
+	})
+
+	body, ok := debugTimingsResponse()
+	if !ok {
+		t.Fatalf("expected debugTimingsResponse to be enabled")
+	}
+
+	var entries []requestMetrics
+	if err := json.Unmarshal(body, &entries); err != nil {
+		t.Fatalf("failed to unmarshal debug ring response: %v", err)
+	}
+	if len(entries) != debugRingSize {
+		t.Fatalf("debug ring has %d entries, want capped at %d", len(entries), debugRingSize)
+	}
+}
+
+func TestDebugTimingsResponseReportsDisabledWhenEnvUnset(t *testing.T) {
+	resetDebugRing(t)
+	withDebugTimingsEnabled(t, false)
+
+	if _, ok := debugTimingsResponse(); ok {
+		t.Fatalf("expected debugTimingsResponse to report disabled when %s is unset", debugTimingsEnv)
+	}
+}
+
+func TestEmitEMFWritesValidJSONWithExpectedFields(t *testing.T) {
+	m := requestMetrics{
+		CorrelationID: "req-1",
+		ObjectKey:     "/a.png",
+		Backend:       "go",
+		Format:        "jpeg",
+		CacheHit:      true,
+		SourceBytes:   1024,
+	}
+
+	out := captureStdout(t, func() { emitEMF(m) })
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace([]byte(out)), &doc); err != nil {
+		t.Fatalf("emitEMF did not produce valid JSON: %v\noutput: %s", err, out)
+	}
+	if _, ok := doc["_aws"]; !ok {
+		t.Errorf("emitEMF output missing \"_aws\" EMF metadata block: %s", out)
+	}
+	if doc["Backend"] != "go" || doc["Format"] != "jpeg" {
+		t.Errorf("emitEMF dimension fields = Backend=%v Format=%v, want go/jpeg", doc["Backend"], doc["Format"])
+	}
+	if doc["CacheHit"] != "true" {
+		t.Errorf("emitEMF CacheHit = %v, want string \"true\"", doc["CacheHit"])
+	}
+}
+
+func TestLogStructuredWritesMetricsAsJSON(t *testing.T) {
+	m := requestMetrics{CorrelationID: "req-2", ObjectKey: "/b.png", Format: "webp"}
+
+	out := captureStdout(t, func() { logStructured(m) })
+
+	var got requestMetrics
+	if err := json.Unmarshal(bytes.TrimSpace([]byte(out)), &got); err != nil {
+		t.Fatalf("logStructured did not produce valid JSON: %v\noutput: %s", err, out)
+	}
+	if got.CorrelationID != m.CorrelationID || got.ObjectKey != m.ObjectKey || got.Format != m.Format {
+		t.Errorf("logStructured round-trip = %+v, want %+v", got, m)
+	}
+}
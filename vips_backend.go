@@ -0,0 +1,216 @@
+//go:build vips
+
+package main
+
+import (
+	"image"
+	"io"
+	"runtime"
+	"time"
+
+	"github.com/davidbyttow/govips/v2/vips"
+	"github.com/disintegration/imaging"
+)
+
+// vipsBackend 는 libvips(govips) 기반 streaming 리사이즈 backend.
+// S3 응답 스트림을 그대로 vips 에 흘려보내 전체 바이트를 메모리에 버퍼링하지 않는다.
+type vipsBackend struct{}
+
+func init() {
+	vips.LoggingSettings(nil, vips.LogLevelWarning)
+	vips.Startup(&vips.Config{
+		// 병적으로 큰 입력에 대비해 invocation 당 동시성/메모리 상한을 명시적으로 둔다
+		ConcurrencyLevel: 1,
+	})
+}
+
+// newVipsBackendIfAvailable 은 vips 빌드 태그가 켜진 바이너리에서 항상 vipsBackend 를 반환한다.
+func newVipsBackendIfAvailable() Backend {
+	return vipsBackend{}
+}
+
+func (vipsBackend) Name() string {
+	return "vips"
+}
+
+func (vipsBackend) Resize(src io.Reader, dst io.Writer, p Params) (Stats, error) {
+	var stats Stats
+
+	limited := io.LimitReader(src, p.MaxSourceBytes+1)
+
+	decodeStart := time.Now()
+	buf, err := io.ReadAll(limited)
+	stats.SourceBytes = int64(len(buf))
+	if err != nil {
+		return stats, err
+	}
+	if stats.SourceBytes > p.MaxSourceBytes {
+		return stats, errSourceTooLarge
+	}
+
+	image, err := vips.NewImageFromBuffer(buf)
+	stats.DecodeDuration = time.Since(decodeStart)
+	if err != nil {
+		return stats, err
+	}
+	defer image.Close()
+
+	// EXIF Orientation 을 반영해 세로로 찍은 사진이 눕지 않도록 한다
+	if err := image.AutoRotate(); err != nil {
+		return stats, err
+	}
+
+	stats.DecodedPixels = int64(image.Width()) * int64(image.Height())
+	if stats.DecodedPixels > p.MaxPixels {
+		return stats, errSourceTooLarge
+	}
+
+	resizeStart := time.Now()
+	err = resizeVips(image, p)
+	stats.ResizeDuration = time.Since(resizeStart)
+	if err != nil {
+		return stats, err
+	}
+
+	encodeStart := time.Now()
+	out, _, err := exportVips(image, p.Format, p.Quality)
+	stats.EncodeDuration = time.Since(encodeStart)
+	if err != nil {
+		return stats, err
+	}
+	stats.OutputBytes = int64(len(out))
+
+	_, err = dst.Write(out)
+	runtime.KeepAlive(buf)
+	return stats, err
+}
+
+// resizeVips 는 fit 모드에 따라 image 를 in-place 로 리사이즈/크롭한다.
+func resizeVips(image *vips.ImageRef, p Params) error {
+	switch p.Fit {
+	case FitContain:
+		return resizeContainVips(image, p.Width, p.Height)
+	case FitCover:
+		scale := maxFloat(float64(p.Width)/float64(image.Width()), float64(p.Height)/float64(image.Height()))
+		if err := image.Resize(scale, vips.KernelLanczos3); err != nil {
+			return err
+		}
+		x, y := cropOffset(image.Width(), image.Height(), p.Width, p.Height, p.Anchor, p.Focus)
+		return image.ExtractArea(x, y, p.Width, p.Height)
+	case FitCrop:
+		w := minInt(p.Width, image.Width())
+		h := minInt(p.Height, image.Height())
+		x, y := cropOffset(image.Width(), image.Height(), w, h, p.Anchor, p.Focus)
+		return image.ExtractArea(x, y, w, h)
+	case FitSmart:
+		return image.SmartCrop(p.Width, p.Height, vips.InterestingAttention)
+	default:
+		if image.Width() <= p.Width {
+			return nil
+		}
+		return image.Resize(float64(p.Width)/float64(image.Width()), vips.KernelLanczos3)
+	}
+}
+
+// resizeContainVips 는 비율을 유지한 채 width x height 박스 안에 들어가도록 축소한다 (레터박스 없음).
+// width, height 가 둘 다 있으면 go 백엔드의 imaging.Fit 처럼 min(width/srcW, height/srcH) 로 스케일하고,
+// 이미 박스보다 작으면 확대하지 않는다. 둘 중 하나만 오면(예: width 만으로 아바타 축소) 나머지는
+// 비율을 유지한 채 imaging.Resize 의 0=비율유지 관례를 그대로 따른다.
+func resizeContainVips(image *vips.ImageRef, width, height int) error {
+	switch {
+	case width <= 0 && height <= 0:
+		return nil
+	case width <= 0:
+		return image.Resize(float64(height)/float64(image.Height()), vips.KernelLanczos3)
+	case height <= 0:
+		return image.Resize(float64(width)/float64(image.Width()), vips.KernelLanczos3)
+	default:
+		scale := minFloat(float64(width)/float64(image.Width()), float64(height)/float64(image.Height()))
+		if scale >= 1 {
+			return nil
+		}
+		return image.Resize(scale, vips.KernelLanczos3)
+	}
+}
+
+// cropOffset 은 focus 가 있으면 focus 좌표를 중심으로, 없으면 anchor(gravity) 기준으로
+// imgW x imgH 안에서 boxW x boxH 크롭의 좌상단 좌표를 계산한다. go 백엔드의
+// cropAtFocus/fillAtFocus, imaging.Fill/imaging.CropAnchor 와 같은 anchor/focus 우선순위를 따른다.
+func cropOffset(imgW, imgH, boxW, boxH int, anchor imaging.Anchor, focus *focusPoint) (int, int) {
+	if focus != nil {
+		cx := int(focus.X * float64(imgW))
+		cy := int(focus.Y * float64(imgH))
+		r := clampRect(image.Rect(0, 0, imgW, imgH), cx, cy, boxW, boxH)
+		return r.Min.X, r.Min.Y
+	}
+	return anchorOffset(imgW, imgH, boxW, boxH, anchor)
+}
+
+// anchorOffset 은 imaging.Anchor(gravity) 에 따라 imgW x imgH 안에서 boxW x boxH 크롭의
+// 좌상단 좌표를 계산한다. imaging.Fill/imaging.CropAnchor 가 gravity 를 해석하는 방식과 동일하다.
+func anchorOffset(imgW, imgH, boxW, boxH int, anchor imaging.Anchor) (int, int) {
+	x := (imgW - boxW) / 2
+	y := (imgH - boxH) / 2
+
+	switch anchor {
+	case imaging.Top:
+		y = 0
+	case imaging.Bottom:
+		y = imgH - boxH
+	case imaging.Left:
+		x = 0
+	case imaging.Right:
+		x = imgW - boxW
+	case imaging.TopLeft:
+		x, y = 0, 0
+	case imaging.TopRight:
+		x, y = imgW-boxW, 0
+	case imaging.BottomLeft:
+		x, y = 0, imgH-boxH
+	case imaging.BottomRight:
+		x, y = imgW-boxW, imgH-boxH
+	}
+
+	return maxInt(0, x), maxInt(0, y)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// exportVips 는 협상된 format 에 맞는 vips export params 를 선택해 인코딩한다.
+func exportVips(image *vips.ImageRef, format string, quality int) ([]byte, *vips.ImageMetadata, error) {
+	switch format {
+	case "webp":
+		ep := vips.NewWebpExportParams()
+		ep.Quality = quality
+		return image.ExportWebp(ep)
+	case "avif":
+		ep := vips.NewAvifExportParams()
+		ep.Quality = quality
+		return image.ExportAvif(ep)
+	case "png":
+		return image.ExportPng(vips.NewPngExportParams())
+	default:
+		ep := vips.NewJpegExportParams()
+		ep.Quality = quality
+		return image.ExportJpeg(ep)
+	}
+}
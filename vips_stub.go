@@ -0,0 +1,9 @@
+//go:build !vips
+
+package main
+
+// newVipsBackendIfAvailable 는 vips 빌드 태그 없이 컴파일된 바이너리(로컬 개발, vips
+// 레이어가 없는 Lambda)에서 호출된다 - nil 을 반환해 순수 Go backend 로 폴백하게 한다.
+func newVipsBackendIfAvailable() Backend {
+	return nil
+}